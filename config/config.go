@@ -0,0 +1,77 @@
+// Package config persists the watch configuration used by Sentinel's daemon
+// lifecycle commands: install writes it out, and the running service loads
+// it again on every start so it always reflects what install was given.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config mirrors the watch options a user would otherwise pass on the
+// command line.
+type Config struct {
+	Paths     []string `yaml:"paths"`
+	Create    bool     `yaml:"create"`
+	Write     bool     `yaml:"write"`
+	Delete    bool     `yaml:"delete"`
+	Rename    bool     `yaml:"rename"`
+	Chmod     bool     `yaml:"chmod"`
+	Loop      bool     `yaml:"loop"`
+	Recursive bool     `yaml:"recursive"`
+	Poll      string   `yaml:"poll,omitempty"`
+	Debounce  string   `yaml:"debounce,omitempty"`
+
+	CreateAction string `yaml:"create_action,omitempty"`
+	WriteAction  string `yaml:"write_action,omitempty"`
+	DeleteAction string `yaml:"delete_action,omitempty"`
+	RenameAction string `yaml:"rename_action,omitempty"`
+	ChmodAction  string `yaml:"chmod_action,omitempty"`
+	ScriptAction string `yaml:"script_action,omitempty"`
+
+	Include      []string `yaml:"include,omitempty"`
+	Exclude      []string `yaml:"exclude,omitempty"`
+	Jobs         int      `yaml:"jobs,omitempty"`
+	KillPrevious bool     `yaml:"kill_previous,omitempty"`
+}
+
+// DefaultPath returns where the service-managed configuration is read from
+// and written to on this platform.
+func DefaultPath() string {
+	switch runtime.GOOS {
+	case "windows":
+		return `C:\ProgramData\sentinel\sentinel.yml`
+	case "darwin":
+		return "/Library/Application Support/Sentinel/sentinel.yml"
+	default:
+		return "/etc/sentinel/sentinel.yml"
+	}
+}
+
+// Load reads and parses a Config from path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to path, creating its parent directory if needed.
+func Save(cfg *Config, path string) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
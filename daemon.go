@@ -0,0 +1,220 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Urethramancer/Sentinel/config"
+	"github.com/kardianos/service"
+)
+
+var svcConfig = &service.Config{
+	Name:        "sentinel",
+	DisplayName: "Sentinel",
+	Description: "Watches directories and runs scripts in response to filesystem changes.",
+}
+
+// daemonProgram adapts Sentinel's watch loop to the kardianos/service
+// Program interface, so it can run under systemd, launchd, or the Windows
+// service manager without main() knowing which one it is.
+type daemonProgram struct {
+	stop chan struct{}
+}
+
+func (p *daemonProgram) Start(s service.Service) error {
+	go run(p.stop)
+	return nil
+}
+
+func (p *daemonProgram) Stop(s service.Service) error {
+	close(p.stop)
+	return nil
+}
+
+// newService builds the service handle for the current daemon configuration.
+func newService() (service.Service, error) {
+	return service.New(&daemonProgram{stop: make(chan struct{})}, svcConfig)
+}
+
+// configFromOpts snapshots the watch configuration given on the command
+// line, for persisting at install time.
+func configFromOpts() *config.Config {
+	cfg := &config.Config{
+		Paths:        opts.Args.Directory,
+		Create:       opts.Flags.Create,
+		Write:        opts.Flags.Write,
+		Delete:       opts.Flags.Delete,
+		Rename:       opts.Flags.Rename,
+		Chmod:        opts.Flags.Chmod,
+		Loop:         opts.Other.Loop,
+		Recursive:    opts.Other.Recursive,
+		CreateAction: opts.Commands.CreateAction,
+		WriteAction:  opts.Commands.WriteAction,
+		DeleteAction: opts.Commands.DeleteAction,
+		RenameAction: opts.Commands.RenameAction,
+		ChmodAction:  opts.Commands.ChmodAction,
+		ScriptAction: opts.Commands.ScriptAction,
+		Include:      opts.Filters.Include,
+		Exclude:      opts.Filters.Exclude,
+		Jobs:         opts.Other.Jobs,
+		KillPrevious: opts.Other.KillPrevious,
+	}
+	if opts.Poll > 0 {
+		cfg.Poll = opts.Poll.String()
+	}
+	if opts.Debounce > 0 {
+		cfg.Debounce = opts.Debounce.String()
+	}
+	return cfg
+}
+
+// applyConfig loads a persisted Config into opts, so run() sees the same
+// watch configuration that was given at install time.
+func applyConfig(cfg *config.Config) {
+	opts.Args.Directory = cfg.Paths
+	opts.Flags.Create = cfg.Create
+	opts.Flags.Write = cfg.Write
+	opts.Flags.Delete = cfg.Delete
+	opts.Flags.Rename = cfg.Rename
+	opts.Flags.Chmod = cfg.Chmod
+	opts.Other.Loop = cfg.Loop
+	opts.Other.Recursive = cfg.Recursive
+	opts.Commands.CreateAction = cfg.CreateAction
+	opts.Commands.WriteAction = cfg.WriteAction
+	opts.Commands.DeleteAction = cfg.DeleteAction
+	opts.Commands.RenameAction = cfg.RenameAction
+	opts.Commands.ChmodAction = cfg.ChmodAction
+	opts.Commands.ScriptAction = cfg.ScriptAction
+	opts.Filters.Include = cfg.Include
+	opts.Filters.Exclude = cfg.Exclude
+	opts.Other.Jobs = cfg.Jobs
+	opts.Other.KillPrevious = cfg.KillPrevious
+
+	if cfg.Poll != "" {
+		if d, err := time.ParseDuration(cfg.Poll); err == nil {
+			opts.Poll = d
+		}
+	}
+	if cfg.Debounce != "" {
+		if d, err := time.ParseDuration(cfg.Debounce); err == nil {
+			opts.Debounce = d
+		}
+	}
+}
+
+// loadInstalledConfig loads the persisted configuration so a process started
+// by the OS service manager watches the same paths install was given.
+func loadInstalledConfig() *config.Config {
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return &config.Config{}
+	}
+	return cfg
+}
+
+// runningAsService reports whether this process was launched by the OS
+// service manager (systemd, launchd, the Windows SCM) rather than run
+// interactively from a shell. If so, it loads the persisted configuration
+// and blocks, running the watch loop through the service lifecycle until
+// the manager stops it.
+func runningAsService() bool {
+	if service.Interactive() {
+		return false
+	}
+
+	applyConfig(loadInstalledConfig())
+	prg := &daemonProgram{stop: make(chan struct{})}
+	svc, err := service.New(prg, svcConfig)
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err := svc.Run(); err != nil {
+		fatal(err.Error())
+	}
+	return true
+}
+
+// DaemonInstallCommand implements `sentinel install`.
+type DaemonInstallCommand struct{}
+
+// Execute persists the watch configuration given on this command line and
+// registers Sentinel as a system service.
+func (c *DaemonInstallCommand) Execute(args []string) error {
+	cfg := configFromOpts()
+	if err := config.Save(cfg, config.DefaultPath()); err != nil {
+		return err
+	}
+	svc, err := newService()
+	if err != nil {
+		return err
+	}
+	if err := svc.Install(); err != nil {
+		return err
+	}
+	pr("Installed. Configuration saved to %s.\n", config.DefaultPath())
+	return nil
+}
+
+// DaemonRemoveCommand implements `sentinel remove`.
+type DaemonRemoveCommand struct{}
+
+// Execute removes the installed service. The persisted configuration is
+// left in place in case the service is reinstalled later.
+func (c *DaemonRemoveCommand) Execute(args []string) error {
+	svc, err := newService()
+	if err != nil {
+		return err
+	}
+	return svc.Uninstall()
+}
+
+// DaemonStartCommand implements `sentinel start`.
+type DaemonStartCommand struct{}
+
+// Execute starts the installed service via the OS service manager.
+func (c *DaemonStartCommand) Execute(args []string) error {
+	svc, err := newService()
+	if err != nil {
+		return err
+	}
+	return svc.Start()
+}
+
+// DaemonStopCommand implements `sentinel stop`.
+type DaemonStopCommand struct{}
+
+// Execute stops the installed service via the OS service manager.
+func (c *DaemonStopCommand) Execute(args []string) error {
+	svc, err := newService()
+	if err != nil {
+		return err
+	}
+	return svc.Stop()
+}
+
+// DaemonStatusCommand implements `sentinel status`.
+type DaemonStatusCommand struct{}
+
+// Execute reports whether the installed service is running.
+func (c *DaemonStatusCommand) Execute(args []string) error {
+	svc, err := newService()
+	if err != nil {
+		return err
+	}
+	status, err := svc.Status()
+	if err != nil {
+		return err
+	}
+	pr("%s\n", statusString(status))
+	return nil
+}
+
+func statusString(status service.Status) string {
+	switch status {
+	case service.StatusRunning:
+		return "running"
+	case service.StatusStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
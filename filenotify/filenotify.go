@@ -0,0 +1,21 @@
+// Package filenotify abstracts filesystem watching behind a single
+// interface so Sentinel can fall back to polling on filesystems fsnotify
+// can't watch (NFS, SMB, FUSE mounts, or containers with inotify exhausted
+// or unavailable), mirroring the approach used by Docker's pkg/filenotify.
+package filenotify
+
+import "github.com/fsnotify/fsnotify"
+
+// FileWatcher is implemented by every watch backend Sentinel can use.
+type FileWatcher interface {
+	// Events returns the channel on which filesystem events are delivered.
+	Events() <-chan fsnotify.Event
+	// Errors returns the channel on which watch errors are delivered.
+	Errors() <-chan error
+	// Add starts watching name.
+	Add(name string) error
+	// Remove stops watching name.
+	Remove(name string) error
+	// Close releases the watcher and any resources it holds.
+	Close() error
+}
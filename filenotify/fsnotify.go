@@ -0,0 +1,26 @@
+package filenotify
+
+import "github.com/fsnotify/fsnotify"
+
+// fsNotifyWatcher adapts *fsnotify.Watcher to the FileWatcher interface.
+type fsNotifyWatcher struct {
+	*fsnotify.Watcher
+}
+
+// New returns a FileWatcher backed by the OS's native filesystem
+// notification facility (inotify, kqueue, ReadDirectoryChangesW).
+func New() (FileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &fsNotifyWatcher{w}, nil
+}
+
+func (w *fsNotifyWatcher) Events() <-chan fsnotify.Event {
+	return w.Watcher.Events
+}
+
+func (w *fsNotifyWatcher) Errors() <-chan error {
+	return w.Watcher.Errors
+}
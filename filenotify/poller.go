@@ -0,0 +1,194 @@
+package filenotify
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pollWatcher is a FileWatcher that periodically restats its tracked paths
+// and synthesizes fsnotify-style events by diffing the result against a
+// cached snapshot, for filesystems where OS-level notifications don't work.
+type pollWatcher struct {
+	interval time.Duration
+	events   chan fsnotify.Event
+	errs     chan error
+
+	mu    sync.Mutex
+	dirs  map[string]map[string]os.FileInfo
+	files map[string]os.FileInfo
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPollingWatcher returns a FileWatcher that polls every interval instead
+// of relying on OS-level filesystem notifications.
+func NewPollingWatcher(interval time.Duration) FileWatcher {
+	w := &pollWatcher{
+		interval: interval,
+		events:   make(chan fsnotify.Event),
+		errs:     make(chan error),
+		dirs:     make(map[string]map[string]os.FileInfo),
+		files:    make(map[string]os.FileInfo),
+		closeCh:  make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.loop()
+	return w
+}
+
+func (w *pollWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *pollWatcher) Errors() <-chan error          { return w.errs }
+
+func (w *pollWatcher) Add(name string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if info.IsDir() {
+		w.dirs[name] = listDir(name)
+	} else {
+		w.files[name] = info
+	}
+	return nil
+}
+
+func (w *pollWatcher) Remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.dirs, name)
+	delete(w.files, name)
+	return nil
+}
+
+// Close stops the polling loop and closes the Events/Errors channels, to
+// match the same contract the fsnotify-backed watcher provides (Watcher.Close
+// closes its channels too): watch()'s consumer goroutine relies on receiving
+// !ok from both once a watcher is closed to know when to stop. It waits for
+// loop to actually exit before closing them, since closing a channel while
+// emit/fail is still blocked trying to send on it would panic.
+func (w *pollWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+		w.wg.Wait()
+		close(w.events)
+		close(w.errs)
+	})
+	return nil
+}
+
+func (w *pollWatcher) loop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *pollWatcher) poll() {
+	w.mu.Lock()
+	dirs := make(map[string]map[string]os.FileInfo, len(w.dirs))
+	for dir, snap := range w.dirs {
+		dirs[dir] = snap
+	}
+	files := make(map[string]os.FileInfo, len(w.files))
+	for name, info := range w.files {
+		files[name] = info
+	}
+	w.mu.Unlock()
+
+	for dir, prev := range dirs {
+		cur := listDir(dir)
+		for name, info := range cur {
+			if old, ok := prev[name]; ok {
+				w.diff(name, old, info)
+			} else {
+				w.emit(fsnotify.Event{Name: name, Op: fsnotify.Create})
+			}
+		}
+		for name := range prev {
+			if _, ok := cur[name]; !ok {
+				w.emit(fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			}
+		}
+		w.mu.Lock()
+		w.dirs[dir] = cur
+		w.mu.Unlock()
+	}
+
+	for name, prev := range files {
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			w.emit(fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			w.mu.Lock()
+			delete(w.files, name)
+			w.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			w.fail(err)
+			continue
+		}
+		w.diff(name, prev, info)
+		w.mu.Lock()
+		w.files[name] = info
+		w.mu.Unlock()
+	}
+}
+
+// diff compares old and current stat results for the same path and emits
+// the appropriate write/chmod events.
+func (w *pollWatcher) diff(name string, old, info os.FileInfo) {
+	if info.ModTime() != old.ModTime() || info.Size() != old.Size() {
+		w.emit(fsnotify.Event{Name: name, Op: fsnotify.Write})
+	}
+	if info.Mode() != old.Mode() {
+		w.emit(fsnotify.Event{Name: name, Op: fsnotify.Chmod})
+	}
+}
+
+func (w *pollWatcher) emit(event fsnotify.Event) {
+	select {
+	case w.events <- event:
+	case <-w.closeCh:
+	}
+}
+
+func (w *pollWatcher) fail(err error) {
+	select {
+	case w.errs <- err:
+	case <-w.closeCh:
+	}
+}
+
+// listDir returns a snapshot of dir's immediate children keyed by full path.
+// Directories that can't be read yield an empty snapshot rather than an
+// error, since the next poll will simply see every entry as newly created.
+func listDir(dir string) map[string]os.FileInfo {
+	snap := make(map[string]os.FileInfo)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return snap
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snap[filepath.Join(dir, entry.Name())] = info
+	}
+	return snap
+}
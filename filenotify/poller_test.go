@@ -0,0 +1,77 @@
+package filenotify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// drainEvent waits up to a short timeout for an event matching op on name,
+// ignoring any others (for a watched file the poller may also report Chmod
+// or Remove, which the caller does not care about).
+func drainEvent(t *testing.T, w FileWatcher, name string, op fsnotify.Op) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-w.Events():
+			if ev.Name == name && ev.Op&op == op {
+				return
+			}
+		case err := <-w.Errors():
+			t.Fatalf("unexpected error: %s", err)
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s on %s", op, name)
+		}
+	}
+}
+
+func TestPollWatcherDetectsWrite(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "watched.txt")
+	if err := os.WriteFile(name, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewPollingWatcher(10 * time.Millisecond)
+	defer w.Close()
+
+	if err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(name, []byte("two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	drainEvent(t, w, name, fsnotify.Write)
+}
+
+func TestPollWatcherCloseClosesChannels(t *testing.T) {
+	w := NewPollingWatcher(5 * time.Millisecond)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	select {
+	case _, ok := <-w.Events():
+		if ok {
+			t.Fatal("Events() should be closed after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out reading from Events() after Close")
+	}
+
+	select {
+	case _, ok := <-w.Errors():
+		if ok {
+			t.Fatal("Errors() should be closed after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out reading from Errors() after Close")
+	}
+}
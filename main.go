@@ -3,9 +3,17 @@ package main
 import (
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/Urethramancer/Sentinel/filenotify"
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/fsnotify/fsnotify"
+	"github.com/google/shlex"
 	"github.com/jessevdk/go-flags"
 )
 
@@ -21,9 +29,11 @@ const (
 var Version = "0.0.0"
 
 var opts struct {
-	Verbose bool `short:"v" long:"verbose" description:"Print more details during operation, otherwise remain quiet until an error occurs."`
-	Version bool `short:"V" long:"version" description:"Show program version and exit."`
-	Flags   struct {
+	Verbose  bool          `short:"v" long:"verbose" description:"Print more details during operation, otherwise remain quiet until an error occurs."`
+	Version  bool          `short:"V" long:"version" description:"Show program version and exit."`
+	Poll     time.Duration `long:"poll" description:"Use a polling watcher with the given interval instead of OS filesystem notifications. Useful on NFS, SMB, FUSE mounts, or containers where inotify is unavailable or exhausted." value-name:"DURATION"`
+	Debounce time.Duration `long:"debounce" description:"Collapse bursts of events for the same path and action into a single dispatch after this quiet period passes with no further events (e.g. --debounce=500ms)." value-name:"DURATION"`
+	Flags    struct {
 		Create bool `short:"c" long:"create" description:"Watch for new files."`
 		Write  bool `short:"w" long:"write" description:"Watch for changed files."`
 		Delete bool `short:"d" long:"delete" description:"Watch for deletion."`
@@ -31,7 +41,10 @@ var opts struct {
 		Chmod  bool `short:"m" long:"chmod" description:"Watch for attribute changes (date or permissions)."`
 	} `group:"Trigger flags"`
 	Other struct {
-		Loop bool `short:"L" long:"loop" description:"Don't quit after each triggered event."`
+		Loop         bool `short:"L" long:"loop" description:"Don't quit after each triggered event."`
+		Recursive    bool `short:"x" long:"recursive" description:"Watch PATH and all of its subdirectories, tracking new and removed subtrees as they appear."`
+		Jobs         int  `short:"j" long:"jobs" default:"1" description:"Run up to this many triggered scripts concurrently, instead of blocking the watch loop on each one until it exits." value-name:"N"`
+		KillPrevious bool `long:"kill-previous" description:"If a new event arrives for a path and action whose previous invocation is still running, terminate it (SIGTERM, then SIGKILL after a grace period) instead of letting both run."`
 	}
 	Commands struct {
 		CreateAction string `short:"C" long:"createaction" description:"Script to run when a file is created. Implies -c." value-name:"SCRIPT"`
@@ -41,6 +54,10 @@ var opts struct {
 		ChmodAction  string `short:"M" long:"chmodaction" description:"Script to run when a file's date or permissions change. Implies -m." value-name:"SCRIPT"`
 		ScriptAction string `short:"S" long:"scriptaction" description:"Script to run for all events. Requires any of the trigger flags. Overrides the other scripts." value-name:"SCRIPT"`
 	} `group:"Scripts"`
+	Filters struct {
+		Include []string `long:"include" description:"Only dispatch for paths matching this glob (doublestar-style, e.g. '**/*.go'). Repeatable; a path must match at least one include, if any are given." value-name:"GLOB"`
+		Exclude []string `long:"exclude" description:"Never dispatch for paths matching this glob, checked after --include. Repeatable." value-name:"GLOB"`
+	} `group:"Filters"`
 	Args struct {
 		Directory []string `positional-arg-name:"PATH"`
 	} `positional-args:"yes"`
@@ -48,8 +65,54 @@ var opts struct {
 
 var done = make(chan bool)
 
+// running tracks scripts launched by the worker pool so shutdown can drain
+// them instead of exiting out from under an in-flight invocation.
+var running sync.WaitGroup
+
+// workers is the pool launched scripts run through; (re)created by run().
+var workers *pool
+
+// quitRequested is closed when a launched script asks Sentinel to shut
+// down (see waitCommand), so run() can exit through the same orderly
+// watcher.Close/drain path as a signal or the non-loop "done" case.
+var quitRequested = make(chan struct{})
+var quitOnce sync.Once
+
+func requestQuit() {
+	quitOnce.Do(func() { close(quitRequested) })
+}
+
+var parser = flags.NewParser(&opts, flags.Default)
+
+// daemonCommands are dispatched by hand in main() rather than registered
+// on parser with AddCommand: opts.Args.Directory is a root-level slice
+// positional, and in go-flags a slice positional is never considered
+// "filled", so its parseNonOption branch always wins over command lookup
+// regardless of SubcommandsOptional. AddCommand-registered commands would
+// parse without error but never actually run, silently watching the
+// command name itself as a PATH instead.
+var daemonCommands = map[string]flags.Commander{
+	"install": &DaemonInstallCommand{},
+	"remove":  &DaemonRemoveCommand{},
+	"start":   &DaemonStartCommand{},
+	"stop":    &DaemonStopCommand{},
+	"status":  &DaemonStatusCommand{},
+}
+
 func main() {
-	_, err := flags.Parse(&opts)
+	if len(os.Args) > 1 {
+		if cmd, ok := daemonCommands[os.Args[1]]; ok {
+			if _, err := parser.ParseArgs(os.Args[2:]); err != nil {
+				return
+			}
+			if err := cmd.Execute(nil); err != nil {
+				fatal(err.Error())
+			}
+			return
+		}
+	}
+
+	_, err := parser.Parse()
 	if err != nil {
 		return
 	}
@@ -59,6 +122,17 @@ func main() {
 		return
 	}
 
+	if runningAsService() {
+		return
+	}
+	run(nil)
+}
+
+// run wires up the watcher from the current opts and blocks until a
+// triggered event ends it (non-loop mode), stop is closed by a service
+// manager, or the process receives SIGINT/SIGTERM.
+func run(stop <-chan struct{}) {
+	var err error
 	if len(opts.Args.Directory) == 0 {
 		warn("No paths specified.")
 	}
@@ -121,95 +195,520 @@ func main() {
 		opts.Commands.WriteAction = opts.Commands.ScriptAction
 	}
 
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return
+	var watcher filenotify.FileWatcher
+	if opts.Poll > 0 {
+		v("Polling every %s.\n", opts.Poll)
+		watcher = filenotify.NewPollingWatcher(opts.Poll)
+	} else {
+		watcher, err = filenotify.New()
+		if err != nil {
+			v("fsnotify unavailable (%s), falling back to polling.\n", err.Error())
+			watcher = filenotify.NewPollingWatcher(time.Second)
+		}
 	}
-	defer watcher.Close()
+
+	workers = newPool(opts.Other.Jobs)
 
 	watch(watcher, flags)
 	for _, dir := range paths {
-		v("* %s\n", dir)
-		err = watcher.Add(dir)
+		if opts.Other.Recursive {
+			err = addTree(watcher, dir)
+		} else {
+			v("* %s\n", dir)
+			err = watcher.Add(dir)
+		}
 		if err != nil {
 			fatal(err.Error())
 		}
 	}
 
-	// We'll never return from this without a break signal if in loop mode
-	<-done
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	// In loop mode, only a signal, a script's shutdown request or a service
+	// stop ends this.
+	select {
+	case <-done:
+	case <-sig:
+		v("Signal received, shutting down.\n")
+	case <-quitRequested:
+	case <-stop:
+		v("Service stop requested, shutting down.\n")
+	}
+
+	watcher.Close()
+	bounce.flush()
+	running.Wait()
 }
 
-func watch(watcher *fsnotify.Watcher, flags fsnotify.Op) {
+// watch consumes the watcher's event and error channels until watcher.Close
+// closes them. It must check ok on both receives: once closed, a channel
+// yields its zero value forever, and treating a nil error as a real one
+// would panic on err.Error().
+func watch(watcher filenotify.FileWatcher, flags fsnotify.Op) {
 	go func() {
 		for {
 			select {
-			case event := <-watcher.Events:
-				if flags&event.Op&fsnotify.Create == fsnotify.Create {
-					launch("create", opts.Commands.CreateAction, event)
+			case event, ok := <-watcher.Events():
+				if !ok {
+					return
 				}
-				if flags&event.Op&fsnotify.Write == fsnotify.Write {
-					launch("write", opts.Commands.WriteAction, event)
+				handleEvent(watcher, flags, event)
+			case err, ok := <-watcher.Errors():
+				if !ok {
+					return
 				}
-				if flags&event.Op&fsnotify.Remove == fsnotify.Remove {
-					launch("delete", opts.Commands.DeleteAction, event)
-				}
-				if flags&event.Op&fsnotify.Rename == fsnotify.Rename {
-					launch("rename", opts.Commands.RenameAction, event)
-				}
-				if flags&event.Op&fsnotify.Chmod == fsnotify.Chmod {
-					launch("chmod", opts.Commands.ChmodAction, event)
-				}
-			case err := <-watcher.Errors:
-				if err.Error() != "" {
-					fatal("Error: ", err.Error())
-				}
-				done <- true
+				fatal("Error: ", err.Error())
 			}
 		}
 	}()
 }
 
-func launch(action, cmd string, event fsnotify.Event) {
-	if cmd != "" {
-		var err error
-		v("CHMOD: Running '%s'\n", cmd)
-		err = os.Setenv(ACTION, action)
+// handleEvent reacts to a single filesystem event, maintaining the
+// recursive watch tree if enabled and launching any configured action.
+func handleEvent(watcher filenotify.FileWatcher, flags fsnotify.Op, event fsnotify.Event) {
+	if opts.Other.Recursive {
+		switch {
+		case event.Op&fsnotify.Create == fsnotify.Create:
+			if isDir(event.Name) {
+				go rescanTree(watcher, flags, event.Name)
+			}
+		case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			watcher.Remove(event.Name)
+		}
+	}
+	if !matchesFilters(event.Name) {
+		return
+	}
+
+	if flags&event.Op&fsnotify.Create == fsnotify.Create {
+		dispatch("create", opts.Commands.CreateAction, event)
+	}
+	if flags&event.Op&fsnotify.Write == fsnotify.Write {
+		dispatch("write", opts.Commands.WriteAction, event)
+	}
+	if flags&event.Op&fsnotify.Remove == fsnotify.Remove {
+		dispatch("delete", opts.Commands.DeleteAction, event)
+	}
+	if flags&event.Op&fsnotify.Rename == fsnotify.Rename {
+		dispatch("rename", opts.Commands.RenameAction, event)
+	}
+	if flags&event.Op&fsnotify.Chmod == fsnotify.Chmod {
+		dispatch("chmod", opts.Commands.ChmodAction, event)
+	}
+}
+
+// matchesFilters reports whether path should be dispatched, given the
+// current --include/--exclude globs: if any --include globs are set, path
+// must match at least one of them; it must then match none of --exclude.
+func matchesFilters(path string) bool {
+	if len(opts.Filters.Include) > 0 {
+		included := false
+		for _, pattern := range opts.Filters.Include {
+			if ok, _ := doublestar.Match(pattern, path); ok {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, pattern := range opts.Filters.Exclude {
+		if ok, _ := doublestar.Match(pattern, path); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatch launches cmd for event, coalescing it through the debouncer when
+// --debounce is set. An empty cmd always goes straight to launch so the
+// non-loop "quit after the first matching event" behaviour stays immediate.
+func dispatch(action, cmd string, event fsnotify.Event) {
+	if opts.Debounce > 0 && cmd != "" {
+		bounce.schedule(action, event, func(ev fsnotify.Event) {
+			launch(action, cmd, ev)
+		})
+		return
+	}
+	launch(action, cmd, event)
+}
+
+var bounce = newDebouncer()
+
+// debouncer coalesces bursts of events for the same (path, action) pair into
+// a single dispatch once a quiet period passes with no further events. This
+// fixes the well-known fsnotify double-write problem and prevents launching
+// a script for every event in an atomic-save or bulk-checkout burst.
+type debouncer struct {
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	pending map[string]pendingEvent
+}
+
+// pendingEvent is a debounced event still waiting out its quiet period,
+// along with the fire callback schedule was given for it.
+type pendingEvent struct {
+	event fsnotify.Event
+	fire  func(fsnotify.Event)
+}
+
+func newDebouncer() *debouncer {
+	return &debouncer{
+		timers:  make(map[string]*time.Timer),
+		pending: make(map[string]pendingEvent),
+	}
+}
+
+// schedule records event under (event.Name, action), keeping the
+// highest-priority Op seen, and (re)starts the quiet-period timer. fire is
+// called once, after opts.Debounce has passed with no further events for
+// that key.
+func (d *debouncer) schedule(action string, event fsnotify.Event, fire func(fsnotify.Event)) {
+	key := event.Name + "|" + action
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if p, ok := d.pending[key]; ok {
+		if opPriority(event.Op) > opPriority(p.event.Op) {
+			p.event.Op = event.Op
+		}
+		d.pending[key] = p
+	} else {
+		d.pending[key] = pendingEvent{event: event, fire: fire}
+	}
+
+	if t, ok := d.timers[key]; ok {
+		t.Stop()
+	}
+	d.timers[key] = time.AfterFunc(opts.Debounce, func() {
+		d.mu.Lock()
+		p := d.pending[key]
+		delete(d.pending, key)
+		delete(d.timers, key)
+		d.mu.Unlock()
+		p.fire(p.event)
+	})
+}
+
+// flush stops every pending debounce timer and fires its event immediately,
+// rather than letting it run out its quiet period. Used on shutdown so an
+// event buffered in an open debounce window isn't silently dropped when run
+// exits.
+func (d *debouncer) flush() {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[string]pendingEvent)
+	for _, t := range d.timers {
+		t.Stop()
+	}
+	d.timers = make(map[string]*time.Timer)
+	d.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	v("Flushing %d pending debounced event(s) before shutdown.\n", len(pending))
+	for _, p := range pending {
+		p.fire(p.event)
+	}
+}
+
+// opPriority ranks fsnotify ops so a debounce window keeps the most
+// significant one seen, e.g. a deletion outranks a trailing write.
+func opPriority(op fsnotify.Op) int {
+	switch {
+	case op&fsnotify.Remove == fsnotify.Remove:
+		return 5
+	case op&fsnotify.Rename == fsnotify.Rename:
+		return 4
+	case op&fsnotify.Create == fsnotify.Create:
+		return 3
+	case op&fsnotify.Chmod == fsnotify.Chmod:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// addTree walks dir and registers a watch on it and every subdirectory
+// beneath it, since fsnotify only ever watches a single level.
+func addTree(watcher filenotify.FileWatcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fatal("Couldn't set environment variable: %s", err.Error())
+			return err
+		}
+		if info.IsDir() {
+			v("* %s\n", path)
+			return watcher.Add(path)
 		}
-		err = os.Setenv(PATH, event.Name)
+		return nil
+	})
+}
+
+// rescanTree registers watches for a newly created directory and everything
+// under it, then synthesizes a create event for each file it finds. This
+// closes the race where files land in the directory before our watcher.Add
+// for it has taken effect.
+func rescanTree(watcher filenotify.FileWatcher, flags fsnotify.Op, dir string) {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fatal("Couldn't set environment variable: %s", err.Error())
+			return err
+		}
+		if info.IsDir() {
+			v("* %s\n", path)
+			return watcher.Add(path)
 		}
-		runCommand(cmd)
+		handleEvent(watcher, flags, fsnotify.Event{Name: path, Op: fsnotify.Create})
+		return nil
+	})
+	if err != nil {
+		v("Error: %s\n", err)
 	}
-	if !opts.Other.Loop {
-		done <- true
+}
+
+// isDir reports whether path currently exists and is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// killGrace is how long a --kill-previous invocation is given to exit after
+// SIGTERM before pool follows up with SIGKILL.
+const killGrace = 5 * time.Second
+
+// pool runs launched scripts through a bounded set of workers instead of
+// blocking the watch loop's goroutine on each cmd.Wait(). Submitting blocks
+// once every worker is busy, so --jobs bounds concurrency rather than just
+// limiting it.
+type pool struct {
+	jobs chan job
+
+	mu      sync.Mutex
+	current map[string]*invocation
+	locks   map[string]*sync.Mutex
+}
+
+// invocation pairs a running *exec.Cmd with a channel that's closed once
+// it's actually been reaped (in run(), after cmd.Wait() returns). Gating
+// killCurrent's SIGKILL on done rather than a blind sleep+signal avoids
+// firing it at a PID the kernel has since recycled for an unrelated
+// process: the Process.Signal call only happens if done hasn't closed,
+// meaning this exact *exec.Cmd is confirmed not yet reaped.
+type invocation struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+type job struct {
+	action string
+	cmd    string
+	event  fsnotify.Event
+}
+
+// newPool starts n workers pulling from a shared job queue.
+func newPool(n int) *pool {
+	if n < 1 {
+		n = 1
+	}
+	p := &pool{
+		jobs:    make(chan job),
+		current: make(map[string]*invocation),
+		locks:   make(map[string]*sync.Mutex),
+	}
+	for i := 0; i < n; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *pool) worker() {
+	for j := range p.jobs {
+		p.run(j)
 	}
 }
 
-func runCommand(script string) {
-	cmd := exec.Command("bash", script)
+// submit enqueues a script invocation, blocking until a worker picks it up.
+// running is incremented here, before the job is handed off, so a caller
+// that calls running.Wait() right after submit returns (e.g. the non-loop
+// shutdown path in run()) can't race the worker goroutine and observe a
+// count of zero before the worker gets a chance to record that it's busy.
+func (p *pool) submit(action, cmd string, event fsnotify.Event) {
+	running.Add(1)
+	p.jobs <- job{action: action, cmd: cmd, event: event}
+}
+
+func (p *pool) run(j job) {
+	defer running.Done()
+
+	key := j.event.Name + "|" + j.action
+	cmd := buildCommand(j.cmd, j.action, j.event)
+	if cmd == nil {
+		return
+	}
+
+	inv := &invocation{cmd: cmd, done: make(chan struct{})}
+	defer func() {
+		p.mu.Lock()
+		if p.current[key] == inv {
+			delete(p.current, key)
+		}
+		p.mu.Unlock()
+		close(inv.done)
+	}()
+
+	// The per-key lock is held from killing the previous invocation through
+	// starting this one (not for the whole run): that's the window a worker
+	// racing on the same key under --jobs > 1 needs excluded, so it can't
+	// register itself in between and end up running alongside the old one.
+	// Holding it any longer would block that racing worker's own kill of
+	// *this* invocation until this one finished on its own, defeating
+	// --kill-previous entirely.
+	var lk *sync.Mutex
+	if opts.Other.KillPrevious {
+		lk = p.keyLock(key)
+		lk.Lock()
+		p.killCurrent(key)
+	}
+
+	p.mu.Lock()
+	p.current[key] = inv
+	p.mu.Unlock()
+
+	v("%s: Running '%s'\n", j.action, j.cmd)
 	err := cmd.Start()
+	if lk != nil {
+		lk.Unlock()
+	}
+
 	if err != nil {
 		v("Error: %s\n", err)
+		return
 	}
+	waitCommand(cmd)
+}
 
-	err = cmd.Wait()
-	if err != nil {
-		exit, ok := err.(*exec.ExitError)
-		if ok {
-			status, ok := exit.Sys().(syscall.WaitStatus)
-			if ok {
-				if status == 256 || status == 512 {
-					os.Exit(0)
-					v("Exit code: %d\n", status)
-				}
-			}
-		} else {
-			v("Error: %s\n", err)
-		}
+// keyLock returns the mutex that serializes --kill-previous invocations for
+// key, creating it on first use.
+func (p *pool) keyLock(key string) *sync.Mutex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lk, ok := p.locks[key]
+	if !ok {
+		lk = &sync.Mutex{}
+		p.locks[key] = lk
 	}
+	return lk
+}
+
+// killCurrent terminates any invocation still running for key: SIGTERM
+// first, then SIGKILL if it hasn't exited within killGrace. Used when
+// --kill-previous is set and a new event arrives for the same path and
+// action while the previous script is still running. It blocks until the
+// invocation has actually been reaped, so the caller can safely register
+// its own invocation under key once killCurrent returns.
+func (p *pool) killCurrent(key string) {
+	p.mu.Lock()
+	inv, ok := p.current[key]
+	p.mu.Unlock()
+	if !ok || inv.cmd.Process == nil {
+		return
+	}
+
+	inv.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-inv.done:
+		return
+	case <-time.After(killGrace):
+	}
+
+	inv.cmd.Process.Signal(syscall.SIGKILL)
+	<-inv.done
+}
+
+func launch(action, cmd string, event fsnotify.Event) {
+	if cmd != "" {
+		workers.submit(action, cmd, event)
+	}
+	if !opts.Other.Loop {
+		done <- true
+	}
+}
+
+// buildCommand turns line, a command string that may use the {path},
+// {action}, {base}, {dir} and {ext} placeholders, into an *exec.Cmd ready
+// to run. A bare script path with no arguments is still run through bash,
+// as before, so scripts without an executable bit keep working; anything
+// with arguments is split into an argv and run directly, e.g.
+// `-W 'gofmt -w {path}'`. SENTINEL_ACTION and SENTINEL_PATH are set on the
+// command's own environment (not the process-wide one) so concurrent jobs
+// from the worker pool don't race over them.
+func buildCommand(line, action string, event fsnotify.Event) *exec.Cmd {
+	name, args, err := tokenize(line)
+	if err != nil || name == "" {
+		v("Error: couldn't parse command %q: %s\n", line, err)
+		return nil
+	}
+
+	name = expandTemplate(name, action, event)
+	for i, arg := range args {
+		args[i] = expandTemplate(arg, action, event)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), ACTION+"="+action, PATH+"="+event.Name)
+	return cmd
+}
+
+// tokenize splits a raw command template into a program name and its
+// arguments. This happens before placeholder substitution: {path} and
+// friends can expand to values containing spaces or quotes, and splitting
+// the already-substituted string would then either break one path into
+// several argv entries or make shlex choke on an unbalanced quote.
+func tokenize(line string) (string, []string, error) {
+	if !strings.ContainsAny(line, " \t") {
+		return "bash", []string{line}, nil
+	}
+	fields, err := shlex.Split(line)
+	if err != nil || len(fields) == 0 {
+		return "", nil, err
+	}
+	return fields[0], fields[1:], nil
+}
+
+// waitCommand waits for cmd to finish. A couple of well-known exit codes
+// (1 and 2) are a long-standing convention scripts can use to ask Sentinel
+// itself to shut down, rather than just the one event that launched them.
+func waitCommand(cmd *exec.Cmd) {
+	err := cmd.Wait()
+	if err == nil {
+		return
+	}
+	exit, ok := err.(*exec.ExitError)
+	if !ok {
+		v("Error: %s\n", err)
+		return
+	}
+	status, ok := exit.Sys().(syscall.WaitStatus)
+	if ok && (status == 256 || status == 512) {
+		v("Exit code: %d, requesting shutdown.\n", status.ExitStatus())
+		requestQuit()
+	}
+}
 
+// expandTemplate substitutes per-event placeholders into cmd: {path} the
+// full event path, {action} the trigger name, {base} its filename, {dir}
+// its containing directory, and {ext} its extension.
+func expandTemplate(cmd, action string, event fsnotify.Event) string {
+	r := strings.NewReplacer(
+		"{path}", event.Name,
+		"{action}", action,
+		"{base}", filepath.Base(event.Name),
+		"{dir}", filepath.Dir(event.Name),
+		"{ext}", filepath.Ext(event.Name),
+	)
+	return r.Replace(cmd)
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestTokenizeBareScriptGoesThroughBash(t *testing.T) {
+	name, args, err := tokenize("{path}")
+	if err != nil {
+		t.Fatalf("tokenize: %s", err)
+	}
+	if name != "bash" || len(args) != 1 || args[0] != "{path}" {
+		t.Fatalf("got name=%q args=%v, want bash [{path}]", name, args)
+	}
+}
+
+// TestTokenizeBeforeSubstitution is the core of the chunk0-5 fix: a path
+// with a space or a quote, reaching {path}, must stay a single argument
+// because tokenization happens on the raw template, not the substituted
+// command line.
+func TestTokenizeBeforeSubstitution(t *testing.T) {
+	name, args, err := tokenize("gofmt -w {path}")
+	if err != nil {
+		t.Fatalf("tokenize: %s", err)
+	}
+	if name != "gofmt" {
+		t.Fatalf("name = %q, want gofmt", name)
+	}
+
+	event := fsnotify.Event{Name: "my file's notes.go"}
+	for i, a := range args {
+		args[i] = expandTemplate(a, "write", event)
+	}
+
+	want := []string{"-w", "my file's notes.go"}
+	if len(args) != len(want) || args[0] != want[0] || args[1] != want[1] {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	event := fsnotify.Event{Name: filepath.Join("dir", "file.go")}
+	got := expandTemplate("{action}:{path}:{base}:{dir}:{ext}", "write", event)
+	want := "write:" + event.Name + ":file.go:dir:.go"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMatchesFiltersIncludeExclude(t *testing.T) {
+	old := opts.Filters
+	defer func() { opts.Filters = old }()
+
+	opts.Filters.Include = []string{"**/*.go"}
+	opts.Filters.Exclude = []string{"**/*_test.go"}
+
+	cases := map[string]bool{
+		"pkg/main.go":      true,
+		"pkg/main_test.go": false,
+		"pkg/readme.md":    false,
+	}
+	for path, want := range cases {
+		if got := matchesFilters(path); got != want {
+			t.Errorf("matchesFilters(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMatchesFiltersNoIncludeMatchesEverythingButExclude(t *testing.T) {
+	old := opts.Filters
+	defer func() { opts.Filters = old }()
+
+	opts.Filters.Include = nil
+	opts.Filters.Exclude = []string{"**/*.log"}
+
+	if !matchesFilters("pkg/main.go") {
+		t.Error("expected a path with no include globs set to match")
+	}
+	if matchesFilters("pkg/debug.log") {
+		t.Error("expected an excluded path not to match")
+	}
+}
+
+func TestDebouncerCoalescesAndKeepsHighestPriorityOp(t *testing.T) {
+	old := opts.Debounce
+	opts.Debounce = 20 * time.Millisecond
+	defer func() { opts.Debounce = old }()
+
+	d := newDebouncer()
+	fired := make(chan fsnotify.Event, 1)
+	fire := func(ev fsnotify.Event) { fired <- ev }
+
+	d.schedule("write", fsnotify.Event{Name: "f", Op: fsnotify.Write}, fire)
+	d.schedule("write", fsnotify.Event{Name: "f", Op: fsnotify.Remove}, fire)
+
+	select {
+	case ev := <-fired:
+		if ev.Op != fsnotify.Remove {
+			t.Fatalf("got op %v, want %v (higher priority should win)", ev.Op, fsnotify.Remove)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for debounced fire")
+	}
+
+	select {
+	case <-fired:
+		t.Fatal("fire called twice for a single debounce window")
+	default:
+	}
+}
+
+// TestDebouncerFlush covers the chunk0-3 shutdown fix: a pending debounce
+// window must fire immediately on flush rather than being silently dropped.
+func TestDebouncerFlush(t *testing.T) {
+	old := opts.Debounce
+	opts.Debounce = time.Minute
+	defer func() { opts.Debounce = old }()
+
+	d := newDebouncer()
+	fired := make(chan fsnotify.Event, 1)
+	d.schedule("write", fsnotify.Event{Name: "f", Op: fsnotify.Write}, func(ev fsnotify.Event) {
+		fired <- ev
+	})
+
+	d.flush()
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("flush did not fire the pending event")
+	}
+
+	if len(d.pending) != 0 || len(d.timers) != 0 {
+		t.Fatal("flush should clear pending and timers")
+	}
+}
+
+// TestPoolKillPreviousTerminatesOldInvocation covers the chunk0-6 fix: a
+// second event for the same (path, action) key must terminate the still
+// running previous invocation well before it would have exited on its own.
+func TestPoolKillPreviousTerminatesOldInvocation(t *testing.T) {
+	oldKP := opts.Other.KillPrevious
+	opts.Other.KillPrevious = true
+	defer func() { opts.Other.KillPrevious = oldKP }()
+
+	p := newPool(2)
+	event := fsnotify.Event{Name: "testkey"}
+
+	start := time.Now()
+	p.submit("write", "sleep 2", event)
+	time.Sleep(150 * time.Millisecond) // let the worker register and start the sleep
+	p.submit("write", "echo done", event)
+
+	finished := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for both invocations to finish")
+	}
+
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Fatalf("first invocation ran for %s; --kill-previous should have terminated it long before its own 2s sleep completed", elapsed)
+	}
+
+	if _, ok := p.current["testkey|write"]; ok {
+		t.Fatal("pool.current should be empty once both invocations have finished")
+	}
+}